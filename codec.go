@@ -0,0 +1,171 @@
+// codec support beyond the two stdlib formats main.go started with:
+// gif, bmp, tiff decode/encode, and webp decode (x/image/webp has no
+// encoder). Encoding goes through a pluggable Encoder interface so callers
+// can register their own formats (e.g. AVIF) the same way these are
+// registered below.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp" // decode-only: registers "webp" with image.Decode
+)
+
+// ErrUnsupportedFormat is returned when a requested format has no
+// registered Encoder (or, from load, was undecodable). Callers can
+// errors.As against it to recover the offending format string.
+type ErrUnsupportedFormat struct {
+	Format string
+}
+
+func (e *ErrUnsupportedFormat) Error() string {
+	return fmt.Sprintf("unsupported format: %s", e.Format)
+}
+
+// EncodeOptions carries per-format encoding knobs. Fields irrelevant to
+// the target format are ignored.
+type EncodeOptions struct {
+	// JPEGQuality is 1-100; 0 uses jpeg.DefaultQuality.
+	JPEGQuality int
+	// PNGCompression selects a png.CompressionLevel; the zero value is
+	// png.DefaultCompression.
+	PNGCompression png.CompressionLevel
+	// GIFNumColors bounds the palette size; 0 uses 256.
+	GIFNumColors int
+	// GIFQuantizer overrides the default palette quantizer; nil leaves it
+	// up to image/gif, which quantizes with palette.Plan9 and dithers the
+	// result with draw.FloydSteinberg.
+	GIFQuantizer draw.Quantizer
+}
+
+// Encoder writes an image out in a specific format.
+type Encoder interface {
+	Encode(w io.Writer, img image.Image, opts EncodeOptions) error
+}
+
+// encoderRegistry maps a format name (as returned by image.Decode, or as
+// passed to SaveAs) to the Encoder that handles it.
+var encoderRegistry = map[string]Encoder{}
+
+// RegisterEncoder adds or replaces the Encoder used for format.
+func RegisterEncoder(format string, encoder Encoder) {
+	encoderRegistry[format] = encoder
+}
+
+func init() {
+	RegisterEncoder("png", pngEncoder{})
+	RegisterEncoder("jpeg", jpegEncoder{})
+	RegisterEncoder("gif", gifEncoder{})
+	RegisterEncoder("bmp", bmpEncoder{})
+	RegisterEncoder("tiff", tiffEncoder{})
+}
+
+type pngEncoder struct{}
+
+func (pngEncoder) Encode(w io.Writer, img image.Image, opts EncodeOptions) error {
+	enc := png.Encoder{CompressionLevel: opts.PNGCompression}
+	return enc.Encode(w, img)
+}
+
+type jpegEncoder struct{}
+
+func (jpegEncoder) Encode(w io.Writer, img image.Image, opts EncodeOptions) error {
+	quality := opts.JPEGQuality
+	if quality <= 0 {
+		quality = jpeg.DefaultQuality
+	}
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+}
+
+type gifEncoder struct{}
+
+func (gifEncoder) Encode(w io.Writer, img image.Image, opts EncodeOptions) error {
+	numColors := opts.GIFNumColors
+	if numColors <= 0 {
+		numColors = 256
+	}
+	return gif.Encode(w, img, &gif.Options{NumColors: numColors, Quantizer: opts.GIFQuantizer})
+}
+
+type bmpEncoder struct{}
+
+func (bmpEncoder) Encode(w io.Writer, img image.Image, _ EncodeOptions) error {
+	return bmp.Encode(w, img)
+}
+
+type tiffEncoder struct{}
+
+func (tiffEncoder) Encode(w io.Writer, img image.Image, _ EncodeOptions) error {
+	return tiff.Encode(w, img, nil)
+}
+
+// formatFromExt maps a file extension (as returned by filepath.Ext) to the
+// format name used by encoderRegistry, or "" if unrecognized.
+func formatFromExt(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return "png"
+	case ".jpg", ".jpeg":
+		return "jpeg"
+	case ".gif":
+		return "gif"
+	case ".bmp":
+		return "bmp"
+	case ".tif", ".tiff":
+		return "tiff"
+	case ".webp":
+		return "webp"
+	default:
+		return ""
+	}
+}
+
+// SaveAs writes the image to path in the given format, detecting the
+// format from path's extension when format is empty. Unlike save, this
+// also updates i.filepath and i.format on success so later calls default
+// to the new location.
+func (i *TrackedImage) SaveAs(path string, format string, opts EncodeOptions) error {
+	if i.data == nil {
+		return errors.New("no image data, you must load the image first")
+	}
+
+	if format == "" {
+		format = formatFromExt(path)
+	}
+	encoder, ok := encoderRegistry[format]
+	if !ok {
+		return &ErrUnsupportedFormat{Format: format}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		err := file.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}(file)
+
+	if err := encoder.Encode(file, i.data, opts); err != nil {
+		return err
+	}
+	i.filepath = path
+	i.format = format
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatFromExt(t *testing.T) {
+	cases := map[string]string{
+		"photo.PNG":  "png",
+		"photo.jpg":  "jpeg",
+		"photo.jpeg": "jpeg",
+		"photo.gif":  "gif",
+		"photo.bmp":  "bmp",
+		"photo.tiff": "tiff",
+		"photo.webp": "webp",
+		"photo.xyz":  "",
+	}
+	for path, want := range cases {
+		if got := formatFromExt(path); got != want {
+			t.Errorf("formatFromExt(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestSaveAsDetectsFormatFromExtension(t *testing.T) {
+	img := &TrackedImage{data: newSolidRGBA(4, 4, color.RGBA{R: 255, A: 255})}
+	path := filepath.Join(t.TempDir(), "out.bmp")
+
+	if err := img.SaveAs(path, "", EncodeOptions{}); err != nil {
+		t.Fatalf("SaveAs returned error: %v", err)
+	}
+	if img.format != "bmp" {
+		t.Errorf("SaveAs left format = %q, want %q", img.format, "bmp")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("SaveAs did not write %s: %v", path, err)
+	}
+}
+
+func TestSaveAsUnsupportedFormat(t *testing.T) {
+	img := &TrackedImage{data: newSolidRGBA(2, 2, color.RGBA{A: 255})}
+	path := filepath.Join(t.TempDir(), "out.avif")
+
+	err := img.SaveAs(path, "", EncodeOptions{})
+	var unsupported *ErrUnsupportedFormat
+	if !errors.As(err, &unsupported) {
+		t.Errorf("SaveAs error = %v, want *ErrUnsupportedFormat", err)
+	}
+}
@@ -0,0 +1,380 @@
+// convolution-based effects: a general Convolve primitive plus the usual
+// named presets built on top of it (blur, sharpen, edge-detect, emboss).
+
+package main
+
+import (
+	"errors"
+	"image"
+	"math"
+	"runtime"
+	"sync"
+)
+
+// EdgeHandling controls how Convolve samples outside the image bounds.
+type EdgeHandling int
+
+const (
+	// Extend clamps out-of-bounds coordinates to the nearest edge pixel.
+	Extend EdgeHandling = iota
+	// Wrap samples from the opposite edge (toroidal wraparound).
+	Wrap
+	// Zero treats out-of-bounds samples as fully transparent black.
+	Zero
+)
+
+// ConvolveOptions controls edge handling and kernel normalization for
+// Convolve.
+type ConvolveOptions struct {
+	Edge      EdgeHandling
+	Normalize bool
+}
+
+// resolveEdge maps a possibly out-of-bounds (x, y) to an in-bounds source
+// coordinate per the given edge handling. ok is false only for Zero when
+// the coordinate falls outside the image.
+func resolveEdge(x, y, w, h int, edge EdgeHandling) (int, int, bool) {
+	if x >= 0 && x < w && y >= 0 && y < h {
+		return x, y, true
+	}
+	switch edge {
+	case Wrap:
+		x = ((x % w) + w) % w
+		y = ((y % h) + h) % h
+		return x, y, true
+	case Zero:
+		return 0, 0, false
+	default: // Extend
+		if x < 0 {
+			x = 0
+		} else if x >= w {
+			x = w - 1
+		}
+		if y < 0 {
+			y = 0
+		} else if y >= h {
+			y = h - 1
+		}
+		return x, y, true
+	}
+}
+
+// normalizeKernel scales kernel so its weights sum to 1, leaving it
+// unchanged if the sum is zero (e.g. edge-detect kernels).
+func normalizeKernel(kernel [][]float64) [][]float64 {
+	var sum float64
+	for _, row := range kernel {
+		for _, v := range row {
+			sum += v
+		}
+	}
+	if sum == 0 {
+		return kernel
+	}
+	normalized := make([][]float64, len(kernel))
+	for y, row := range kernel {
+		normalized[y] = make([]float64, len(row))
+		for x, v := range row {
+			normalized[y][x] = v / sum
+		}
+	}
+	return normalized
+}
+
+// Convolve applies an arbitrary 2-D kernel to the image. Edge handling and
+// optional normalization are controlled via options.
+func (i *TrackedImage) Convolve(kernel [][]float64, options ConvolveOptions) error {
+	if i.data == nil {
+		return errors.New("no image data, you must load the image first")
+	}
+	if len(kernel) == 0 || len(kernel[0]) == 0 {
+		return errors.New("convolution kernel must not be empty")
+	}
+
+	if options.Normalize {
+		kernel = normalizeKernel(kernel)
+	}
+
+	src := toRGBA(i.data)
+	raw := convolveRaw(src, kernel, options.Edge)
+
+	bounds := src.Bounds()
+	out := image.NewRGBA(bounds)
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			_, _, _, a := src.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			c := raw[y][x]
+			out.Set(bounds.Min.X+x, bounds.Min.Y+y, premultipliedRGBA64(c[0], c[1], c[2], float64(a)))
+		}
+	}
+	i.data = out
+	return nil
+}
+
+// convolveRaw applies kernel to src and returns the unclamped per-channel
+// (r, g, b) accumulator for every pixel, in premultiplied [0,65535] units.
+// Alpha is left untouched by the caller, since convolving it independently
+// tends to produce unwanted edge halos. Rows are split across a worker
+// pool sized to runtime.NumCPU().
+func convolveRaw(src *image.RGBA, kernel [][]float64, edge EdgeHandling) [][][3]float64 {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	kh := len(kernel)
+	kw := len(kernel[0])
+
+	out := make([][][3]float64, h)
+	for y := range out {
+		out[y] = make([][3]float64, w)
+	}
+
+	rows := make(chan int, h)
+	for y := 0; y < h; y++ {
+		rows <- y
+	}
+	close(rows)
+
+	workers := runtime.NumCPU()
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for y := range rows {
+				for x := 0; x < w; x++ {
+					var r, g, b float64
+					for ky := 0; ky < kh; ky++ {
+						for kx := 0; kx < kw; kx++ {
+							sx, sy, ok := resolveEdge(x+kx-kw/2, y+ky-kh/2, w, h, edge)
+							if !ok {
+								continue
+							}
+							pr, pg, pb, _ := src.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+							weight := kernel[ky][kx]
+							r += float64(pr) * weight
+							g += float64(pg) * weight
+							b += float64(pb) * weight
+						}
+					}
+					out[y][x] = [3]float64{r, g, b}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return out
+}
+
+// gaussianWeights returns normalized 1-D Gaussian weights for the given
+// sigma, covering a radius of ceil(3*sigma) on either side of center.
+func gaussianWeights(sigma float64) (weights []float64, radius int) {
+	radius = int(math.Ceil(3 * sigma))
+	if radius < 1 {
+		radius = 1
+	}
+	weights = make([]float64, 2*radius+1)
+	var sum float64
+	for i := -radius; i <= radius; i++ {
+		w := math.Exp(-float64(i*i) / (2 * sigma * sigma))
+		weights[i+radius] = w
+		sum += w
+	}
+	for i := range weights {
+		weights[i] /= sum
+	}
+	return weights, radius
+}
+
+// convolve1D applies a 1-D kernel along one axis only, preserving image
+// dimensions and alpha. Used for the two Gaussian blur passes so the cost
+// stays O(N*k) rather than O(N*k^2) for a full 2-D kernel.
+func convolve1D(src *image.RGBA, weights []float64, radius int, horizontal bool) *image.RGBA {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	out := image.NewRGBA(bounds)
+
+	rows := make(chan int, h)
+	for y := 0; y < h; y++ {
+		rows <- y
+	}
+	close(rows)
+
+	workers := runtime.NumCPU()
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for y := range rows {
+				for x := 0; x < w; x++ {
+					var r, g, b float64
+					for k := -radius; k <= radius; k++ {
+						var sx, sy int
+						if horizontal {
+							sx, sy, _ = resolveEdge(x+k, y, w, h, Extend)
+						} else {
+							sx, sy, _ = resolveEdge(x, y+k, w, h, Extend)
+						}
+						pr, pg, pb, _ := src.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+						weight := weights[k+radius]
+						r += float64(pr) * weight
+						g += float64(pg) * weight
+						b += float64(pb) * weight
+					}
+					_, _, _, a := src.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+					out.Set(bounds.Min.X+x, bounds.Min.Y+y, premultipliedRGBA64(r, g, b, float64(a)))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return out
+}
+
+// GaussianBlur blurs the image with a Gaussian kernel of the given sigma,
+// decomposed into a horizontal then a vertical 1-D pass.
+func (i *TrackedImage) GaussianBlur(sigma float64) error {
+	if i.data == nil {
+		return errors.New("no image data, you must load the image first")
+	}
+	if sigma <= 0 {
+		return errors.New("gaussian blur sigma must be positive")
+	}
+
+	weights, radius := gaussianWeights(sigma)
+	src := toRGBA(i.data)
+	horizontal := convolve1D(src, weights, radius, true)
+	i.data = convolve1D(horizontal, weights, radius, false)
+	return nil
+}
+
+// Sharpen applies a simple 3x3 sharpening kernel, scaled by amount (0
+// leaves the image unchanged; higher values sharpen more aggressively).
+func (i *TrackedImage) Sharpen(amount float64) error {
+	kernel := [][]float64{
+		{0, -amount, 0},
+		{-amount, 1 + 4*amount, -amount},
+		{0, -amount, 0},
+	}
+	return i.Convolve(kernel, ConvolveOptions{Edge: Extend})
+}
+
+// UnsharpMask sharpens by subtracting a Gaussian-blurred copy from the
+// original and adding back amount times the difference, but only where
+// that difference exceeds threshold (a fraction of full channel range,
+// [0,1]). This avoids amplifying near-flat regions of noise.
+func (i *TrackedImage) UnsharpMask(sigma, amount, threshold float64) error {
+	if i.data == nil {
+		return errors.New("no image data, you must load the image first")
+	}
+
+	src := toRGBA(i.data)
+	blurredCopy := TrackedImage{data: cloneRGBA(src)}
+	if err := blurredCopy.GaussianBlur(sigma); err != nil {
+		return err
+	}
+	blurred := blurredCopy.data.(*image.RGBA)
+
+	thresholdScaled := threshold * 65535
+	bounds := src.Bounds()
+	out := image.NewRGBA(bounds)
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			ox, oy := bounds.Min.X+x, bounds.Min.Y+y
+			or, og, ob, oa := src.At(ox, oy).RGBA()
+			br, bg, bb, _ := blurred.At(ox, oy).RGBA()
+
+			r := sharpenChannel(float64(or), float64(br), amount, thresholdScaled)
+			g := sharpenChannel(float64(og), float64(bg), amount, thresholdScaled)
+			b := sharpenChannel(float64(ob), float64(bb), amount, thresholdScaled)
+			out.Set(ox, oy, premultipliedRGBA64(r, g, b, float64(oa)))
+		}
+	}
+	i.data = out
+	return nil
+}
+
+func sharpenChannel(original, blurred, amount, thresholdScaled float64) float64 {
+	diff := original - blurred
+	if math.Abs(diff) <= thresholdScaled {
+		return original
+	}
+	return original + amount*diff
+}
+
+// EdgeDetect replaces the image with the Sobel gradient magnitude,
+// computed per channel and clamped, leaving alpha untouched.
+func (i *TrackedImage) EdgeDetect() error {
+	if i.data == nil {
+		return errors.New("no image data, you must load the image first")
+	}
+
+	sobelX := [][]float64{
+		{-1, 0, 1},
+		{-2, 0, 2},
+		{-1, 0, 1},
+	}
+	sobelY := [][]float64{
+		{-1, -2, -1},
+		{0, 0, 0},
+		{1, 2, 1},
+	}
+
+	src := toRGBA(i.data)
+	gx := convolveRaw(src, sobelX, Extend)
+	gy := convolveRaw(src, sobelY, Extend)
+
+	bounds := src.Bounds()
+	out := image.NewRGBA(bounds)
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			ox, oy := bounds.Min.X+x, bounds.Min.Y+y
+			_, _, _, a := src.At(ox, oy).RGBA()
+			var mag [3]float64
+			for c := 0; c < 3; c++ {
+				mag[c] = math.Hypot(gx[y][x][c], gy[y][x][c])
+			}
+			out.Set(ox, oy, premultipliedRGBA64(mag[0], mag[1], mag[2], float64(a)))
+		}
+	}
+	i.data = out
+	return nil
+}
+
+// Emboss replaces the image with a classic embossed-relief effect: a
+// directional difference kernel (sums to 0, so flat regions convolve to
+// black) biased back up to mid-gray.
+func (i *TrackedImage) Emboss() error {
+	if i.data == nil {
+		return errors.New("no image data, you must load the image first")
+	}
+
+	kernel := [][]float64{
+		{-2, -1, 0},
+		{-1, 0, 1},
+		{0, 1, 2},
+	}
+
+	src := toRGBA(i.data)
+	raw := convolveRaw(src, kernel, Extend)
+
+	const bias = 32768 // mid-gray in premultiplied 16-bit units
+	bounds := src.Bounds()
+	out := image.NewRGBA(bounds)
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			ox, oy := bounds.Min.X+x, bounds.Min.Y+y
+			_, _, _, a := src.At(ox, oy).RGBA()
+			c := raw[y][x]
+			out.Set(ox, oy, premultipliedRGBA64(c[0]+bias, c[1]+bias, c[2]+bias, float64(a)))
+		}
+	}
+	i.data = out
+	return nil
+}
+
+// cloneRGBA makes an independent copy of an *image.RGBA.
+func cloneRGBA(src *image.RGBA) *image.RGBA {
+	dst := image.NewRGBA(src.Bounds())
+	copy(dst.Pix, src.Pix)
+	return dst
+}
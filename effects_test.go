@@ -0,0 +1,38 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+)
+
+// TestEmbossFlatRegionStaysNearGray is a regression test: a zero-sum
+// emboss kernel convolved against a flat region should contribute ~0
+// before the mid-gray bias is added back, so a uniform image stays close
+// to gray instead of saturating to white.
+func TestEmbossFlatRegionStaysNearGray(t *testing.T) {
+	img := &TrackedImage{data: newSolidRGBA(10, 10, color.RGBA{R: 128, G: 128, B: 128, A: 255})}
+
+	if err := img.Emboss(); err != nil {
+		t.Fatalf("Emboss returned error: %v", err)
+	}
+
+	r, g, b, _ := img.data.At(5, 5).RGBA()
+	const midGray = 0x8080
+	const tolerance = 0x0400 // allow for the kernel's +1 diagonal term
+	for _, ch := range []uint32{r, g, b} {
+		if diff := int(ch) - midGray; diff > tolerance || diff < -tolerance {
+			t.Errorf("embossed flat pixel channel = %#x, want close to mid-gray %#x", ch, midGray)
+		}
+	}
+}
+
+func TestGaussianBlurPreservesBounds(t *testing.T) {
+	img := &TrackedImage{data: newSolidRGBA(6, 6, color.RGBA{B: 255, A: 255})}
+
+	if err := img.GaussianBlur(1.5); err != nil {
+		t.Fatalf("GaussianBlur returned error: %v", err)
+	}
+	if got := img.data.Bounds(); got.Dx() != 6 || got.Dy() != 6 {
+		t.Errorf("GaussianBlur changed bounds to %v, want 6x6", got)
+	}
+}
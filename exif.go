@@ -0,0 +1,234 @@
+// EXIF-aware auto-orientation: reads the Orientation tag out of a JPEG's
+// Exif APP1 segment and applies the matching rotation/flip so pixel data
+// and the way the image "looks" agree, regardless of what a viewer does
+// with the (now stale) metadata.
+
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+)
+
+// LoadOptions controls behavior of TrackedImage.load.
+type LoadOptions struct {
+	// AutoOrient applies Reorient automatically after a JPEG with a
+	// non-identity Orientation tag is loaded.
+	AutoOrient bool
+}
+
+// DefaultLoadOptions returns the options load falls back to when the
+// caller doesn't care: auto-orientation on.
+func DefaultLoadOptions() LoadOptions {
+	return LoadOptions{AutoOrient: true}
+}
+
+// readJPEGOrientation scans the raw bytes of a JPEG file for an Exif APP1
+// segment and returns the Orientation tag (1-8). If no Exif segment or no
+// Orientation tag is present, it returns 1 (identity) with no error.
+func readJPEGOrientation(data []byte) (int, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 0, errors.New("not a jpeg file")
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 0, errors.New("malformed jpeg marker")
+		}
+		marker := data[pos+1]
+
+		// markers with no payload
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD9) {
+			pos += 2
+			continue
+		}
+		// start of scan: no more markers worth scanning for metadata
+		if marker == 0xDA {
+			break
+		}
+
+		segLen := int(data[pos+2])<<8 | int(data[pos+3])
+		segStart := pos + 4
+		segEnd := pos + 2 + segLen
+		if segEnd > len(data) {
+			break
+		}
+
+		if marker == 0xE1 { // APP1
+			if orientation, ok := parseExifOrientation(data[segStart:segEnd]); ok {
+				return orientation, nil
+			}
+		}
+		pos = segEnd
+	}
+	return 1, nil
+}
+
+// parseExifOrientation parses an Exif APP1 payload (starting with the
+// "Exif\0\0" marker) and extracts the IFD0 Orientation tag (0x0112).
+func parseExifOrientation(seg []byte) (int, bool) {
+	if len(seg) < 8 || string(seg[:6]) != "Exif\x00\x00" {
+		return 0, false
+	}
+	tiff := seg[6:]
+	if len(tiff) < 8 {
+		return 0, false
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0, false
+	}
+
+	ifdOffset := order.Uint32(tiff[4:8])
+	if int(ifdOffset)+2 > len(tiff) {
+		return 0, false
+	}
+	numEntries := int(order.Uint16(tiff[ifdOffset : ifdOffset+2]))
+	entriesStart := int(ifdOffset) + 2
+
+	for e := 0; e < numEntries; e++ {
+		entryStart := entriesStart + e*12
+		if entryStart+12 > len(tiff) {
+			break
+		}
+		tag := order.Uint16(tiff[entryStart : entryStart+2])
+		if tag != 0x0112 { // Orientation
+			continue
+		}
+		valueOffset := entryStart + 8
+		value := int(order.Uint16(tiff[valueOffset : valueOffset+2]))
+		if value < 1 || value > 8 {
+			return 0, false
+		}
+		return value, true
+	}
+	return 0, false
+}
+
+// Reorient applies the rotation/flip implied by i.Orientation (the eight
+// EXIF cases) and resets Orientation to 1 (identity) once done.
+func (i *TrackedImage) Reorient() error {
+	if i.data == nil {
+		return errors.New("no image data, you must load the image first")
+	}
+
+	switch i.Orientation {
+	case 0, 1:
+		// already identity, nothing to do
+	case 2:
+		i.data = flipHImage(i.data)
+	case 3:
+		i.data = rotate180Image(i.data)
+	case 4:
+		i.data = flipVImage(i.data)
+	case 5:
+		i.data = transposeImage(i.data)
+	case 6:
+		i.data = rotate90CWImage(i.data)
+	case 7:
+		i.data = transverseImage(i.data)
+	case 8:
+		i.data = rotate270CWImage(i.data)
+	default:
+		return fmt.Errorf("unsupported EXIF orientation: %d", i.Orientation)
+	}
+	i.Orientation = 1
+	return nil
+}
+
+func flipHImage(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(w-1-x, y, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+func flipVImage(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(x, h-1-y, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+func rotate180Image(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(w-1-x, h-1-y, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+func rotate90CWImage(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, h, w))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(h-1-y, x, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+func rotate270CWImage(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, h, w))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(y, w-1-x, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// transposeImage mirrors across the top-left/bottom-right diagonal
+// (EXIF orientation 5), swapping width and height.
+func transposeImage(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, h, w))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(y, x, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// transverseImage mirrors across the top-right/bottom-left diagonal
+// (EXIF orientation 7), swapping width and height.
+func transverseImage(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, h, w))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(h-1-y, w-1-x, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+// buildMinimalExifJPEG assembles a byte-minimal JPEG: SOI, an APP1 segment
+// carrying a TIFF header with a single Orientation IFD entry, then SOS so
+// readJPEGOrientation stops scanning.
+func buildMinimalExifJPEG(orientation uint16) []byte {
+	// TIFF header (little-endian) + IFD0 with one entry (Orientation).
+	tiff := []byte{
+		'I', 'I', 0x2A, 0x00, // byte order + magic
+		0x08, 0x00, 0x00, 0x00, // offset to IFD0
+		0x01, 0x00, // 1 entry
+		0x12, 0x01, // tag 0x0112 (Orientation)
+		0x03, 0x00, // type SHORT
+		0x01, 0x00, 0x00, 0x00, // count 1
+		byte(orientation), byte(orientation >> 8), 0x00, 0x00, // value + padding
+		0x00, 0x00, 0x00, 0x00, // next IFD offset (none)
+	}
+
+	app1 := append([]byte("Exif\x00\x00"), tiff...)
+	segLen := len(app1) + 2
+
+	data := []byte{0xFF, 0xD8} // SOI
+	data = append(data, 0xFF, 0xE1, byte(segLen>>8), byte(segLen))
+	data = append(data, app1...)
+	data = append(data, 0xFF, 0xDA) // SOS: stop scanning here
+
+	return data
+}
+
+func TestReadJPEGOrientation(t *testing.T) {
+	for orientation := 1; orientation <= 8; orientation++ {
+		data := buildMinimalExifJPEG(uint16(orientation))
+		got, err := readJPEGOrientation(data)
+		if err != nil {
+			t.Fatalf("orientation %d: unexpected error: %v", orientation, err)
+		}
+		if got != orientation {
+			t.Errorf("orientation %d: readJPEGOrientation = %d", orientation, got)
+		}
+	}
+}
+
+func TestReadJPEGOrientationDefaultsToIdentity(t *testing.T) {
+	data := []byte{0xFF, 0xD8, 0xFF, 0xDA} // SOI then straight to SOS, no APP1
+	got, err := readJPEGOrientation(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != 1 {
+		t.Errorf("readJPEGOrientation = %d, want 1 (identity)", got)
+	}
+}
+
+func TestReadJPEGOrientationRejectsNonJPEG(t *testing.T) {
+	if _, err := readJPEGOrientation([]byte{0x00, 0x01, 0x02, 0x03}); err == nil {
+		t.Error("readJPEGOrientation on non-JPEG data: want error, got nil")
+	}
+}
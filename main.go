@@ -1,16 +1,16 @@
 // tool which allows you to manipulate images of various formats
-// png, jpeg, bmp
+// png, jpeg, gif, bmp, tiff, webp (decode only)
 // save, load images, flip images vertically and horizontally
 
 package main
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"image"
 	"image/color"
-	"image/jpeg"
-	"image/png"
+	"io"
 	"log"
 	"os"
 )
@@ -19,9 +19,14 @@ type TrackedImage struct {
 	filepath string
 	format   string
 	data     image.Image
+	// Orientation is the EXIF Orientation tag (1-8) as read from the
+	// source file, or 1 (identity) for formats without one. Reorient
+	// resets it to 1 once the corresponding transform has been applied,
+	// so it no longer reflects the original tag value after that call.
+	Orientation int
 }
 
-func (i *TrackedImage) load(filepath string) error {
+func (i *TrackedImage) load(filepath string, opts LoadOptions) error {
 	// open file handle
 	file, err := os.Open(filepath)
 	if err != nil {
@@ -35,8 +40,15 @@ func (i *TrackedImage) load(filepath string) error {
 		}
 	}(file)
 
+	// read the whole file up front: we need the raw bytes both to decode
+	// the image and, for JPEGs, to scan for an Exif orientation tag
+	raw, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
 	// image operations
-	decodedImage, format, err := image.Decode(file)
+	decodedImage, format, err := image.Decode(bytes.NewReader(raw))
 	if err != nil {
 		return err
 	}
@@ -44,9 +56,27 @@ func (i *TrackedImage) load(filepath string) error {
 	i.filepath = filepath
 	i.format = format
 	i.data = decodedImage
+	i.Orientation = 1
+
+	if format == "jpeg" {
+		if orientation, err := readJPEGOrientation(raw); err == nil {
+			i.Orientation = orientation
+		}
+	}
+
+	if opts.AutoOrient && i.Orientation != 1 {
+		if err := i.Reorient(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// save writes i.data out to disk using i.format, dispatching through the
+// same encoder registry as SaveAs. Note that none of the built-in encoders
+// write Exif metadata, so the pixel data (already reoriented by load's
+// auto-orient step) and the saved file's metadata can't disagree: there's
+// simply no orientation tag to strip or rewrite.
 func (i *TrackedImage) save(filepath *string) error {
 	// use existing filepath if one is not provided
 	if filepath == nil {
@@ -66,17 +96,11 @@ func (i *TrackedImage) save(filepath *string) error {
 		}
 	}(file)
 
-	// format handling
-	switch i.format {
-	case "png":
-		err = png.Encode(file, i.data)
-		break
-	case "jpeg":
-		err = jpeg.Encode(file, i.data, nil)
-		break
-	default:
-		return fmt.Errorf("unsupported format: %s", i.format)
+	encoder, ok := encoderRegistry[i.format]
+	if !ok {
+		return &ErrUnsupportedFormat{Format: i.format}
 	}
+	err = encoder.Encode(file, i.data, EncodeOptions{})
 
 	if err != nil {
 		return err
@@ -84,16 +108,6 @@ func (i *TrackedImage) save(filepath *string) error {
 	return nil
 }
 
-func (i *TrackedImage) resize(modifier float64) error {
-	if modifier <= 0.1 || (modifier > 1.00 && modifier < 1.01) { // modifiers too small (0.09x - 1.01x)
-		return errors.New("unsupported image resize modifier")
-	}
-	// resize by [modifier]x
-	// whether it be 0.1x (smallest), 0.5x, 2x, 4x
-	// TODO implement
-	return nil
-}
-
 func (i *TrackedImage) getPixels() [][]color.Color {
 	if i.data == nil {
 		return nil
@@ -112,11 +126,13 @@ func (i *TrackedImage) getPixels() [][]color.Color {
 }
 
 func (i *TrackedImage) flipHorizontally() error {
-	// same idea as the flipVertically() algorithm, but instead of traversing via the x coordinate,
-	// we use the y coordinate and go col by col instead of row by row.
-	// flip the colors in the exact same way though.
-	// TODO implement
-	return errors.New("horizontal flipping has not been implemented yet")
+	if i.data == nil {
+		return errors.New("no image data, you must load the image first")
+	}
+	w := i.data.Bounds().Dx()
+	// mirror across the vertical axis: dstX = W - srcX, dstY = srcY
+	m := [6]float64{-1, 0, float64(w), 0, 1, 0}
+	return i.transform(m, InterpolatorNearest, color.Transparent)
 }
 func (i *TrackedImage) flipVertically() error {
 	// -- image vertical flip algorithm
@@ -155,24 +171,26 @@ func (i *TrackedImage) flipVertically() error {
 
 func (i *TrackedImage) mirror() error {
 	// flip an image both ways (rotate 180deg)
-	err := i.flipVertically()
-	if err != nil {
-		return err
-	}
-	err = i.flipHorizontally()
-	if err != nil {
-		return err
+	if i.data == nil {
+		return errors.New("no image data, you must load the image first")
 	}
-	return nil
+	bounds := i.data.Bounds()
+	m := [6]float64{-1, 0, float64(bounds.Dx()), 0, -1, float64(bounds.Dy())}
+	return i.transform(m, InterpolatorNearest, color.Transparent)
 }
 
 func main() {
 
-	// TODO command line argument parsing
+	if len(os.Args) > 1 && os.Args[1] == "thumb" {
+		if err := runThumbCommand(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
 	// load image
 	img := TrackedImage{}
-	err := img.load("ss.png")
+	err := img.load("ss.png", DefaultLoadOptions())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,240 @@
+// batch pipeline: run a fixed sequence of operations over every file
+// matched by a glob, across a bounded worker pool, aggregating per-file
+// errors instead of aborting the whole run.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OpKind identifies a single pipeline step.
+type OpKind int
+
+const (
+	OpLoad OpKind = iota
+	OpReorient
+	OpResize
+	OpThumbnail
+	OpFlipHorizontal
+	OpFlipVertical
+	OpMirror
+	OpSave
+)
+
+// PipelineOp is one step of a Pipeline. Only the fields relevant to Kind
+// are read.
+type PipelineOp struct {
+	Kind   OpKind
+	Width  int
+	Height int
+	Filter ResampleFilter
+	Method ThumbMethod
+	// Output is the destination path for OpSave. If empty, the file
+	// being processed is overwritten in place.
+	Output string
+}
+
+// LoadOp loads the file currently being processed.
+func LoadOp() PipelineOp { return PipelineOp{Kind: OpLoad} }
+
+// ReorientOp applies the EXIF orientation recorded at load time.
+func ReorientOp() PipelineOp { return PipelineOp{Kind: OpReorient} }
+
+// ResizeOp scales to an exact width x height using filter.
+func ResizeOp(width, height int, filter ResampleFilter) PipelineOp {
+	return PipelineOp{Kind: OpResize, Width: width, Height: height, Filter: filter}
+}
+
+// ThumbnailOp fits/crops into width x height using method.
+func ThumbnailOp(width, height int, method ThumbMethod) PipelineOp {
+	return PipelineOp{Kind: OpThumbnail, Width: width, Height: height, Method: method}
+}
+
+// FlipHorizontalOp flips the image left-to-right.
+func FlipHorizontalOp() PipelineOp { return PipelineOp{Kind: OpFlipHorizontal} }
+
+// FlipVerticalOp flips the image top-to-bottom.
+func FlipVerticalOp() PipelineOp { return PipelineOp{Kind: OpFlipVertical} }
+
+// MirrorOp rotates the image 180 degrees.
+func MirrorOp() PipelineOp { return PipelineOp{Kind: OpMirror} }
+
+// SaveOp writes the image to output, or back to the source path if output
+// is empty.
+func SaveOp(output string) PipelineOp { return PipelineOp{Kind: OpSave, Output: output} }
+
+// Pipeline is a fixed sequence of operations applied to every file matched
+// by a glob passed to Run.
+type Pipeline struct {
+	Steps []PipelineOp
+	// Workers bounds how many files are processed concurrently. Zero
+	// means runtime.NumCPU().
+	Workers int
+}
+
+// PipelineResult reports the outcome of a Pipeline.Run call.
+type PipelineResult struct {
+	// Successes holds the output path written for each file that made
+	// it through every step.
+	Successes []string
+	// Failures maps an input path to the error that aborted its steps.
+	Failures map[string]error
+}
+
+// Run globs for matching files and runs the pipeline's steps over each,
+// collecting successes and failures independently so one bad file doesn't
+// stop the rest.
+func (p *Pipeline) Run(glob string) (*PipelineResult, error) {
+	matches, err := filepath.Glob(glob)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PipelineResult{Failures: make(map[string]error)}
+	var mu sync.Mutex
+
+	workers := p.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	jobs := make(chan string, len(matches))
+	for _, m := range matches {
+		jobs <- m
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				output, err := p.runOne(path)
+				mu.Lock()
+				if err != nil {
+					result.Failures[path] = err
+				} else {
+					result.Successes = append(result.Successes, output)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return result, nil
+}
+
+// runOne executes every step of the pipeline against a single file,
+// returning the path it was ultimately saved to.
+func (p *Pipeline) runOne(path string) (string, error) {
+	img := &TrackedImage{}
+	outputPath := path
+
+	for _, op := range p.Steps {
+		var err error
+		switch op.Kind {
+		case OpLoad:
+			err = img.load(path, DefaultLoadOptions())
+		case OpReorient:
+			err = img.Reorient()
+		case OpResize:
+			err = img.Resize(op.Width, op.Height, op.Filter)
+		case OpThumbnail:
+			err = img.Thumbnail(op.Width, op.Height, op.Method)
+		case OpFlipHorizontal:
+			err = img.flipHorizontally()
+		case OpFlipVertical:
+			err = img.flipVertically()
+		case OpMirror:
+			err = img.mirror()
+		case OpSave:
+			dest := op.Output
+			if dest == "" {
+				dest = path
+			}
+			outputPath = dest
+			err = img.save(&dest)
+		default:
+			err = fmt.Errorf("unknown pipeline operation: %d", op.Kind)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return outputPath, nil
+}
+
+// runThumbCommand implements `imgtool thumb --size WxH --method scale|crop
+// <glob>...`: it thumbnails every file matched by each glob in place and
+// reports successes/failures to stdout/stderr.
+func runThumbCommand(args []string) error {
+	fs := flag.NewFlagSet("thumb", flag.ExitOnError)
+	size := fs.String("size", "", "thumbnail size as WIDTHxHEIGHT, e.g. 96x96")
+	method := fs.String("method", "scale", "fit method: scale or crop")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	width, height, err := parseSize(*size)
+	if err != nil {
+		return err
+	}
+
+	var m ThumbMethod
+	switch *method {
+	case "scale":
+		m = Scale
+	case "crop":
+		m = Crop
+	default:
+		return fmt.Errorf("unknown thumbnail method: %q (want scale or crop)", *method)
+	}
+
+	globs := fs.Args()
+	if len(globs) == 0 {
+		return errors.New("thumb requires at least one file glob")
+	}
+
+	p := &Pipeline{Steps: []PipelineOp{LoadOp(), ThumbnailOp(width, height, m), SaveOp("")}}
+	for _, g := range globs {
+		result, err := p.Run(g)
+		if err != nil {
+			return err
+		}
+		for path, ferr := range result.Failures {
+			fmt.Fprintf(os.Stderr, "thumb: %s: %v\n", path, ferr)
+		}
+		for _, out := range result.Successes {
+			fmt.Println("Wrote thumbnail:", out)
+		}
+	}
+	return nil
+}
+
+// parseSize parses a "WIDTHxHEIGHT" flag value such as "96x96".
+func parseSize(s string) (int, int, error) {
+	parts := strings.SplitN(s, "x", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid --size %q, expected WIDTHxHEIGHT", s)
+	}
+	width, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid --size %q: %w", s, err)
+	}
+	height, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid --size %q: %w", s, err)
+	}
+	return width, height, nil
+}
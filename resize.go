@@ -0,0 +1,262 @@
+// resampling-based resize support: Resize/ResizeBy plus the set of
+// selectable filters (nearest neighbor, bilinear, bicubic, lanczos)
+
+package main
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"runtime"
+	"sync"
+)
+
+// ResampleFilter describes a 1-D resampling kernel used when scaling an
+// image. Support is the half-width of the kernel (in source-pixel units
+// at 1:1 scale); Kernel evaluates the filter weight at a given distance.
+type ResampleFilter struct {
+	Support float64
+	Kernel  func(float64) float64
+}
+
+var (
+	// NearestNeighbor picks the single closest source pixel.
+	NearestNeighbor = ResampleFilter{
+		Support: 0.5,
+		Kernel:  func(x float64) float64 { return 1 },
+	}
+	// Bilinear linearly interpolates between neighboring pixels.
+	Bilinear = ResampleFilter{
+		Support: 1,
+		Kernel: func(x float64) float64 {
+			x = math.Abs(x)
+			if x < 1 {
+				return 1 - x
+			}
+			return 0
+		},
+	}
+	// Bicubic is the Mitchell-Netravali cubic filter.
+	Bicubic = ResampleFilter{
+		Support: 2,
+		Kernel:  mitchellKernel,
+	}
+	// Lanczos is the Lanczos-3 windowed sinc filter.
+	Lanczos = ResampleFilter{
+		Support: 3,
+		Kernel:  lanczosKernel,
+	}
+)
+
+// mitchellKernel implements the standard piecewise Mitchell-Netravali
+// cubic with B = C = 1/3.
+func mitchellKernel(x float64) float64 {
+	const b = 1.0 / 3.0
+	const c = 1.0 / 3.0
+	x = math.Abs(x)
+	switch {
+	case x < 1:
+		return ((12-9*b-6*c)*x*x*x + (-18+12*b+6*c)*x*x + (6 - 2*b)) / 6
+	case x < 2:
+		return ((-b-6*c)*x*x*x + (6*b+30*c)*x*x + (-12*b-48*c)*x + (8*b + 24*c)) / 6
+	default:
+		return 0
+	}
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	x *= math.Pi
+	return math.Sin(x) / x
+}
+
+func lanczosKernel(x float64) float64 {
+	x = math.Abs(x)
+	if x >= 3 {
+		return 0
+	}
+	return sinc(x) * sinc(x/3)
+}
+
+// contribution is a single (source index, normalized weight) pair used
+// when resampling one destination sample.
+type contribution struct {
+	index  int
+	weight float64
+}
+
+// computeContributions precomputes, for every destination index along an
+// axis, the set of source indices and weights that contribute to it.
+func computeContributions(srcSize, dstSize int, filter ResampleFilter) [][]contribution {
+	scale := float64(srcSize) / float64(dstSize)
+	filterScale := scale
+	if filterScale < 1 {
+		filterScale = 1
+	}
+	support := filter.Support * filterScale
+
+	contributions := make([][]contribution, dstSize)
+	for dst := 0; dst < dstSize; dst++ {
+		center := (float64(dst)+0.5)*scale - 0.5
+		left := int(math.Floor(center - support))
+		right := int(math.Ceil(center + support))
+
+		var weights []contribution
+		var sum float64
+		for src := left; src <= right; src++ {
+			w := filter.Kernel((center - float64(src)) / filterScale)
+			if w == 0 {
+				continue
+			}
+			clamped := src
+			if clamped < 0 {
+				clamped = 0
+			} else if clamped >= srcSize {
+				clamped = srcSize - 1
+			}
+			weights = append(weights, contribution{index: clamped, weight: w})
+			sum += w
+		}
+		if sum != 0 {
+			for i := range weights {
+				weights[i].weight /= sum
+			}
+		}
+		contributions[dst] = weights
+	}
+	return contributions
+}
+
+// resizeAxis resamples src along the X axis into a new width, producing an
+// intermediate *image.RGBA. Rows are split across a worker pool sized to
+// runtime.NumCPU().
+func resizeAxis(src *image.RGBA, dstWidth int, filter ResampleFilter) *image.RGBA {
+	bounds := src.Bounds()
+	height := bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, height))
+	contributions := computeContributions(bounds.Dx(), dstWidth, filter)
+
+	rows := make(chan int, height)
+	for y := 0; y < height; y++ {
+		rows <- y
+	}
+	close(rows)
+
+	workers := runtime.NumCPU()
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for y := range rows {
+				for x := 0; x < dstWidth; x++ {
+					var r, g, b, a float64
+					for _, c := range contributions[x] {
+						pr, pg, pb, pa := src.At(bounds.Min.X+c.index, bounds.Min.Y+y).RGBA()
+						r += float64(pr) * c.weight
+						g += float64(pg) * c.weight
+						b += float64(pb) * c.weight
+						a += float64(pa) * c.weight
+					}
+					dst.Set(x, y, premultipliedRGBA64(r, g, b, a))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return dst
+}
+
+// transposeRGBA swaps the X and Y axes so that resizeAxis, which always
+// resamples along X, can also be used for the vertical pass.
+func transposeRGBA(src *image.RGBA) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dy(), bounds.Dx()))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			dst.Set(y-bounds.Min.Y, x-bounds.Min.X, src.At(x, y))
+		}
+	}
+	return dst
+}
+
+// premultipliedRGBA64 clamps accumulated premultiplied channel sums
+// (as returned by color.Color.RGBA, i.e. in the [0,65535] range) into a
+// color.RGBA64.
+func premultipliedRGBA64(r, g, b, a float64) color.RGBA64 {
+	return color.RGBA64{
+		R: clampChannel(r),
+		G: clampChannel(g),
+		B: clampChannel(b),
+		A: clampChannel(a),
+	}
+}
+
+func clampChannel(v float64) uint16 {
+	if v < 0 {
+		return 0
+	}
+	if v > 65535 {
+		return 65535
+	}
+	return uint16(v + 0.5)
+}
+
+// toRGBA converts an arbitrary image.Image into an *image.RGBA, copying it
+// if necessary.
+func toRGBA(src image.Image) *image.RGBA {
+	if rgba, ok := src.(*image.RGBA); ok {
+		return rgba
+	}
+	bounds := src.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, src, bounds.Min, draw.Src)
+	return dst
+}
+
+// Resize scales the image to exactly width x height using the given
+// resampling filter. The resize is performed as two 1-D passes (horizontal
+// then vertical).
+func (i *TrackedImage) Resize(width, height int, filter ResampleFilter) error {
+	if i.data == nil {
+		return errors.New("no image data, you must load the image first")
+	}
+	if width <= 0 || height <= 0 {
+		return errors.New("resize dimensions must be positive")
+	}
+
+	src := toRGBA(i.data)
+	bounds := src.Bounds()
+
+	// same-size resize is a no-op regardless of filter; this is the same
+	// optimization golang.org/x/image/draw applies for nearest-neighbor.
+	if width == bounds.Dx() && height == bounds.Dy() {
+		i.data = src
+		return nil
+	}
+
+	horizontal := resizeAxis(src, width, filter)
+	vertical := resizeAxis(transposeRGBA(horizontal), height, filter)
+	i.data = transposeRGBA(vertical)
+	return nil
+}
+
+// ResizeBy scales the image by modifier (e.g. 0.5 for half size, 2 for
+// double), computing target dimensions from the current bounds.
+func (i *TrackedImage) ResizeBy(modifier float64, filter ResampleFilter) error {
+	if i.data == nil {
+		return errors.New("no image data, you must load the image first")
+	}
+	if modifier <= 0.1 || (modifier > 1.00 && modifier < 1.01) {
+		return errors.New("unsupported image resize modifier")
+	}
+
+	bounds := i.data.Bounds()
+	width := int(math.Round(float64(bounds.Dx()) * modifier))
+	height := int(math.Round(float64(bounds.Dy()) * modifier))
+	return i.Resize(width, height, filter)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func newSolidRGBA(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestResizeByRejectsUnsupportedModifier(t *testing.T) {
+	img := &TrackedImage{data: newSolidRGBA(4, 4, color.RGBA{R: 255, A: 255})}
+
+	for _, modifier := range []float64{0.05, 1.005} {
+		if err := img.ResizeBy(modifier, Bilinear); err == nil {
+			t.Errorf("ResizeBy(%v) = nil error, want error", modifier)
+		}
+	}
+}
+
+func TestResizeSameSizeIsNoOp(t *testing.T) {
+	src := newSolidRGBA(4, 4, color.RGBA{R: 10, G: 20, B: 30, A: 255})
+	img := &TrackedImage{data: src}
+
+	if err := img.Resize(4, 4, NearestNeighbor); err != nil {
+		t.Fatalf("Resize returned error: %v", err)
+	}
+	if got := img.data.Bounds(); got.Dx() != 4 || got.Dy() != 4 {
+		t.Fatalf("Resize changed bounds to %v, want 4x4", got)
+	}
+	r, g, b, a := img.data.At(1, 1).RGBA()
+	wantR, wantG, wantB, wantA := src.At(1, 1).RGBA()
+	if r != wantR || g != wantG || b != wantB || a != wantA {
+		t.Errorf("Resize same-size changed pixel value: got (%d,%d,%d,%d), want (%d,%d,%d,%d)", r, g, b, a, wantR, wantG, wantB, wantA)
+	}
+}
+
+func TestResizeProducesRequestedDimensions(t *testing.T) {
+	img := &TrackedImage{data: newSolidRGBA(8, 8, color.RGBA{G: 255, A: 255})}
+
+	if err := img.Resize(4, 2, Bilinear); err != nil {
+		t.Fatalf("Resize returned error: %v", err)
+	}
+	got := img.data.Bounds()
+	if got.Dx() != 4 || got.Dy() != 2 {
+		t.Fatalf("Resize produced bounds %v, want 4x2", got)
+	}
+}
@@ -0,0 +1,77 @@
+// thumbnailing: resize into a bounding box either by fitting (preserving
+// aspect ratio) or by filling it and center-cropping the excess.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+)
+
+// ThumbMethod selects how Thumbnail fits the image into the requested box.
+type ThumbMethod int
+
+const (
+	// Scale fits the image within width x height, preserving aspect ratio.
+	// The result may be smaller than the requested box on one axis.
+	Scale ThumbMethod = iota
+	// Crop scales the image to fill width x height, then center-crops
+	// whichever axis overshoots.
+	Crop
+)
+
+// Thumbnail resizes the image to width x height using method.
+func (i *TrackedImage) Thumbnail(width, height int, method ThumbMethod) error {
+	if i.data == nil {
+		return errors.New("no image data, you must load the image first")
+	}
+	if width <= 0 || height <= 0 {
+		return errors.New("thumbnail dimensions must be positive")
+	}
+
+	bounds := i.data.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+
+	switch method {
+	case Scale:
+		scale := math.Min(float64(width)/float64(srcW), float64(height)/float64(srcH))
+		return i.Resize(scaleDim(srcW, scale), scaleDim(srcH, scale), Bilinear)
+	case Crop:
+		scale := math.Max(float64(width)/float64(srcW), float64(height)/float64(srcH))
+		if err := i.Resize(scaleDim(srcW, scale), scaleDim(srcH, scale), Bilinear); err != nil {
+			return err
+		}
+		return i.cropCenter(width, height)
+	default:
+		return fmt.Errorf("unknown thumbnail method: %d", method)
+	}
+}
+
+func scaleDim(dim int, scale float64) int {
+	scaled := int(math.Round(float64(dim) * scale))
+	if scaled < 1 {
+		scaled = 1
+	}
+	return scaled
+}
+
+// cropCenter crops the image down to width x height around its center.
+// width and height must not exceed the current bounds.
+func (i *TrackedImage) cropCenter(width, height int) error {
+	src := toRGBA(i.data)
+	bounds := src.Bounds()
+	if width > bounds.Dx() || height > bounds.Dy() {
+		return errors.New("crop dimensions exceed image bounds")
+	}
+
+	x0 := bounds.Min.X + (bounds.Dx()-width)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-height)/2
+
+	cropped := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(cropped, cropped.Bounds(), src, image.Pt(x0, y0), draw.Src)
+	i.data = cropped
+	return nil
+}
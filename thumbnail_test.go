@@ -0,0 +1,44 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestThumbnailScalePreservesAspect(t *testing.T) {
+	img := &TrackedImage{data: newSolidRGBA(200, 100, color.RGBA{R: 255, A: 255})}
+
+	if err := img.Thumbnail(50, 50, Scale); err != nil {
+		t.Fatalf("Thumbnail returned error: %v", err)
+	}
+	got := img.data.Bounds()
+	if got.Dx() != 50 || got.Dy() != 25 {
+		t.Errorf("Thumbnail(Scale) bounds = %v, want 50x25", got)
+	}
+}
+
+func TestThumbnailCropFillsBox(t *testing.T) {
+	img := &TrackedImage{data: newSolidRGBA(200, 100, color.RGBA{G: 255, A: 255})}
+
+	if err := img.Thumbnail(50, 50, Crop); err != nil {
+		t.Fatalf("Thumbnail returned error: %v", err)
+	}
+	got := img.data.Bounds()
+	if got.Dx() != 50 || got.Dy() != 50 {
+		t.Errorf("Thumbnail(Crop) bounds = %v, want 50x50", got)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	w, h, err := parseSize("96x48")
+	if err != nil {
+		t.Fatalf("parseSize returned error: %v", err)
+	}
+	if w != 96 || h != 48 {
+		t.Errorf("parseSize(\"96x48\") = (%d, %d), want (96, 48)", w, h)
+	}
+
+	if _, _, err := parseSize("not-a-size"); err == nil {
+		t.Error("parseSize(\"not-a-size\"): want error, got nil")
+	}
+}
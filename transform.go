@@ -0,0 +1,217 @@
+// general affine transforms: arbitrary rotation, shear, and the Transform
+// primitive they (and flipHorizontally/mirror) are built on.
+
+package main
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"runtime"
+	"sync"
+)
+
+// Interpolator selects how Transform samples the source image for a
+// destination pixel that doesn't land exactly on a source pixel.
+type Interpolator int
+
+const (
+	// InterpolatorNearest picks the single closest source pixel.
+	InterpolatorNearest Interpolator = iota
+	// InterpolatorBilinear blends the four surrounding source pixels.
+	InterpolatorBilinear
+)
+
+// Transform applies a general affine warp described by m, a row-major
+// 2x3 matrix [a b tx; c d ty] mapping source coordinates to destination
+// coordinates: dst = [a b; c d] * src + [tx; ty]. The destination bounding
+// box is computed by transforming the four source corners; pixels in that
+// box with no corresponding source sample are left transparent.
+func (i *TrackedImage) Transform(m [6]float64, interp Interpolator) error {
+	return i.transform(m, interp, color.Transparent)
+}
+
+// Rotate rotates the image by angleDeg clockwise about its center,
+// growing the canvas to fit, and fills any exposed corners with bg.
+func (i *TrackedImage) Rotate(angleDeg float64, bg color.Color) error {
+	rad := angleDeg * math.Pi / 180
+	cosT, sinT := math.Cos(rad), math.Sin(rad)
+	m := [6]float64{cosT, -sinT, 0, sinT, cosT, 0}
+	return i.transform(m, InterpolatorBilinear, bg)
+}
+
+// Shear skews the image by sx horizontally and sy vertically, growing the
+// canvas to fit and leaving any exposed corners transparent.
+func (i *TrackedImage) Shear(sx, sy float64) error {
+	m := [6]float64{1, sx, 0, sy, 1, 0}
+	return i.transform(m, InterpolatorBilinear, color.Transparent)
+}
+
+// transform is the shared implementation behind Transform, Rotate, Shear,
+// flipHorizontally and mirror.
+func (i *TrackedImage) transform(m [6]float64, interp Interpolator, bg color.Color) error {
+	if i.data == nil {
+		return errors.New("no image data, you must load the image first")
+	}
+
+	a, b, tx, c, d, ty := m[0], m[1], m[2], m[3], m[4], m[5]
+	det := a*d - b*c
+	if det == 0 {
+		return errors.New("transform matrix is not invertible")
+	}
+	ia, ib := d/det, -b/det
+	ic, id := -c/det, a/det
+
+	src := toRGBA(i.data)
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	corners := [4][2]float64{{0, 0}, {float64(w), 0}, {0, float64(h)}, {float64(w), float64(h)}}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range corners {
+		// round away float noise (e.g. cos(90deg) landing on 6e-17 instead
+		// of 0) so a clean rotation doesn't grow the canvas by a pixel
+		dx := roundEpsilon(a*p[0] + b*p[1] + tx)
+		dy := roundEpsilon(c*p[0] + d*p[1] + ty)
+		minX, maxX = math.Min(minX, dx), math.Max(maxX, dx)
+		minY, maxY = math.Min(minY, dy), math.Max(maxY, dy)
+	}
+
+	offsetX := int(math.Floor(minX))
+	offsetY := int(math.Floor(minY))
+	outW := int(math.Ceil(maxX)) - offsetX
+	outH := int(math.Ceil(maxY)) - offsetY
+	if outW < 1 {
+		outW = 1
+	}
+	if outH < 1 {
+		outH = 1
+	}
+
+	bgR, bgG, bgB, bgA := bg.RGBA()
+	bgColor := premultipliedRGBA64(float64(bgR), float64(bgG), float64(bgB), float64(bgA))
+
+	dst := image.NewRGBA(image.Rect(0, 0, outW, outH))
+
+	rows := make(chan int, outH)
+	for y := 0; y < outH; y++ {
+		rows <- y
+	}
+	close(rows)
+
+	workers := runtime.NumCPU()
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for oy := range rows {
+				// sample at pixel centers (+0.5) so the mapping lines up
+				// with the corner-based coordinates used for the bbox
+				dstY := float64(oy+offsetY) + 0.5
+				for ox := 0; ox < outW; ox++ {
+					dstX := float64(ox+offsetX) + 0.5
+					rx := dstX - tx
+					ry := dstY - ty
+					sx := ia*rx + ib*ry
+					sy := ic*rx + id*ry
+
+					if col, ok := sampleSource(src, sx, sy, w, h, interp); ok {
+						dst.Set(ox, oy, col)
+					} else {
+						dst.Set(ox, oy, bgColor)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	i.data = dst
+	return nil
+}
+
+// roundEpsilon snaps a value to 9 decimal places, absorbing the float
+// noise that trig functions leave around values that should be exact
+// (e.g. cos(90deg) landing on ~6.12e-17 instead of 0).
+func roundEpsilon(v float64) float64 {
+	const scale = 1e9
+	return math.Round(v*scale) / scale
+}
+
+// sampleSource samples src at the continuous source coordinate (sx, sy),
+// returning ok=false if that coordinate falls outside the source bounds.
+func sampleSource(src *image.RGBA, sx, sy float64, w, h int, interp Interpolator) (color.RGBA64, bool) {
+	if interp == InterpolatorBilinear {
+		return sampleBilinear(src, sx, sy, w, h)
+	}
+	return sampleNearest(src, sx, sy, w, h)
+}
+
+func sampleNearest(src *image.RGBA, sx, sy float64, w, h int) (color.RGBA64, bool) {
+	x := int(math.Floor(sx))
+	y := int(math.Floor(sy))
+	if x < 0 || x >= w || y < 0 || y >= h {
+		return color.RGBA64{}, false
+	}
+	bounds := src.Bounds()
+	r, g, b, a := src.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+	return premultipliedRGBA64(float64(r), float64(g), float64(b), float64(a)), true
+}
+
+// sampleBilinear fetches the four source samples surrounding (sx, sy) and
+// blends them by the fractional parts of the coordinate, in premultiplied
+// RGBA. Neighbor indices that fall just past the far edge are clamped;
+// (sx, sy) itself landing outside [0,w)x[0,h) is reported as out of bounds.
+func sampleBilinear(src *image.RGBA, sx, sy float64, w, h int) (color.RGBA64, bool) {
+	if sx < 0 || sx >= float64(w) || sy < 0 || sy >= float64(h) {
+		return color.RGBA64{}, false
+	}
+
+	// shift from corner coordinates to pixel-center-indexed coordinates
+	// before interpolating between neighboring centers
+	cx := sx - 0.5
+	cy := sy - 0.5
+	x0 := int(math.Floor(cx))
+	y0 := int(math.Floor(cy))
+	fx := cx - float64(x0)
+	fy := cy - float64(y0)
+	x1, y1 := x0+1, y0+1
+	if x0 < 0 {
+		x0 = 0
+	}
+	if y0 < 0 {
+		y0 = 0
+	}
+	if x1 >= w {
+		x1 = w - 1
+	}
+	if y1 >= h {
+		y1 = h - 1
+	}
+
+	bounds := src.Bounds()
+	sample := func(x, y int) (float64, float64, float64, float64) {
+		r, g, b, a := src.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+		return float64(r), float64(g), float64(b), float64(a)
+	}
+	r00, g00, b00, a00 := sample(x0, y0)
+	r10, g10, b10, a10 := sample(x1, y0)
+	r01, g01, b01, a01 := sample(x0, y1)
+	r11, g11, b11, a11 := sample(x1, y1)
+
+	lerp2D := func(v00, v10, v01, v11 float64) float64 {
+		top := v00*(1-fx) + v10*fx
+		bottom := v01*(1-fx) + v11*fx
+		return top*(1-fy) + bottom*fy
+	}
+
+	r := lerp2D(r00, r10, r01, r11)
+	g := lerp2D(g00, g10, g01, g11)
+	b := lerp2D(b00, b10, b01, b11)
+	aCh := lerp2D(a00, a10, a01, a11)
+
+	return premultipliedRGBA64(r, g, b, aCh), true
+}
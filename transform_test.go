@@ -0,0 +1,42 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestRotate90GrowsCanvasToSwappedDimensions(t *testing.T) {
+	img := &TrackedImage{data: newSolidRGBA(10, 4, color.RGBA{R: 255, A: 255})}
+
+	if err := img.Rotate(90, color.Transparent); err != nil {
+		t.Fatalf("Rotate returned error: %v", err)
+	}
+	got := img.data.Bounds()
+	if got.Dx() != 4 || got.Dy() != 10 {
+		t.Errorf("Rotate(90) bounds = %v, want 4x10", got)
+	}
+}
+
+func TestTransformRejectsSingularMatrix(t *testing.T) {
+	img := &TrackedImage{data: newSolidRGBA(4, 4, color.RGBA{A: 255})}
+
+	// a=1,b=2,c=2,d=4 -> determinant ad-bc = 0
+	m := [6]float64{1, 2, 0, 2, 4, 0}
+	if err := img.Transform(m, InterpolatorNearest); err == nil {
+		t.Error("Transform with singular matrix: want error, got nil")
+	}
+}
+
+func TestFlipHorizontallyIsTransformConsolidation(t *testing.T) {
+	src := newSolidRGBA(4, 4, color.RGBA{R: 255, A: 255})
+	src.Set(0, 0, color.RGBA{G: 255, A: 255}) // mark top-left corner
+	img := &TrackedImage{data: src}
+
+	if err := img.flipHorizontally(); err != nil {
+		t.Fatalf("flipHorizontally returned error: %v", err)
+	}
+	r, g, b, _ := img.data.At(3, 0).RGBA()
+	if r != 0 || g == 0 || b != 0 {
+		t.Errorf("flipHorizontally did not move marked corner to top-right: got (%d,%d,%d)", r, g, b)
+	}
+}